@@ -0,0 +1,259 @@
+// Package httpclient builds and executes the HTTP requests gron
+// makes when its input argument is a URL, with optional request/response
+// logging controlled by the GRON_DEBUG environment variable (modelled
+// on GH_DEBUG).
+package httpclient
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hj3938/gron/pkg/gron"
+)
+
+// Options controls how Get builds and sends its request
+type Options struct {
+	// Headers are added to the request as-is, in "Key: Value" form
+	Headers []string
+	// Method defaults to "GET"
+	Method string
+	// Data is sent as the request body. A leading "@" reads the body
+	// from the named file instead of treating Data as literal content
+	Data string
+	// User is sent as HTTP Basic auth, in "user:password" form
+	User string
+	// Bearer is sent as an "Authorization: Bearer <token>" header
+	Bearer string
+	// Netrc looks up credentials for the request's host in ~/.netrc
+	Netrc bool
+	// Insecure disables TLS certificate verification
+	Insecure bool
+	// Timeout bounds the whole request; zero means no timeout
+	Timeout time.Duration
+}
+
+// Get builds a request for rawurl according to opts, executes it and
+// returns the response body. When GRON_DEBUG is set, the outgoing
+// request and incoming response are dumped to stderr
+func Get(rawurl string, opts *Options) (io.Reader, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	req, err := buildRequest(rawurl, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+	if opts.Insecure {
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+
+	debug := os.Getenv("GRON_DEBUG") != ""
+	if debug {
+		dumpRequest(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !debug {
+		return resp.Body, nil
+	}
+
+	// We've consumed the body to dump it, so hand back a fresh reader
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	dumpResponse(resp, body)
+
+	return strings.NewReader(string(body)), nil
+}
+
+func buildRequest(rawurl string, opts *Options) (*http.Request, error) {
+	method := opts.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	var body io.Reader
+	if opts.Data != "" {
+		data, err := readData(opts.Data)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, rawurl, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %s", err)
+	}
+
+	for _, h := range opts.Headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid header %q, want \"Key: Value\"", h)
+		}
+		req.Header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	switch {
+	case opts.Bearer != "":
+		req.Header.Set("Authorization", "Bearer "+opts.Bearer)
+	case opts.User != "":
+		userPass := strings.SplitN(opts.User, ":", 2)
+		if len(userPass) == 2 {
+			req.SetBasicAuth(userPass[0], userPass[1])
+		} else {
+			req.SetBasicAuth(userPass[0], "")
+		}
+	case opts.Netrc:
+		if user, pass, ok := netrcLookup(req.URL.Hostname()); ok {
+			req.SetBasicAuth(user, pass)
+		}
+	}
+
+	return req, nil
+}
+
+// readData returns opts.Data verbatim, or the contents of a file if
+// it's prefixed with "@"
+func readData(data string) (string, error) {
+	if !strings.HasPrefix(data, "@") {
+		return data, nil
+	}
+	b, err := ioutil.ReadFile(strings.TrimPrefix(data, "@"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %s", err)
+	}
+	return string(b), nil
+}
+
+// netrcLookup finds credentials for host in ~/.netrc
+func netrcLookup(host string) (user, pass string, ok bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", false
+	}
+
+	f, err := os.Open(home + "/.netrc")
+	if err != nil {
+		return "", "", false
+	}
+	defer f.Close()
+
+	fields := strings.Fields(readAll(f))
+	matches := false
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			i++
+			matches = i < len(fields) && fields[i] == host
+		case "login":
+			i++
+			if matches && i < len(fields) {
+				user = fields[i]
+			}
+		case "password":
+			i++
+			if matches && i < len(fields) {
+				pass = fields[i]
+			}
+		}
+	}
+
+	return user, pass, user != "" || pass != ""
+}
+
+func readAll(f *os.File) string {
+	b, _ := ioutil.ReadAll(f)
+	return string(b)
+}
+
+// dumpRequest prints the outgoing request's method/URL, headers and
+// body to stderr. It reads the body via req.GetBody (which
+// http.NewRequest populates for the strings.Reader buildRequest gives
+// it) rather than req.Body, so the real request still has a fresh,
+// unconsumed body to send
+func dumpRequest(req *http.Request) {
+	fmt.Fprintf(os.Stderr, "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+	for k, vs := range req.Header {
+		for _, v := range vs {
+			fmt.Fprintf(os.Stderr, "> %s: %s\n", k, v)
+		}
+	}
+	fmt.Fprintln(os.Stderr, ">")
+
+	if req.GetBody == nil {
+		return
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	body, err := ioutil.ReadAll(rc)
+	if err != nil || len(body) == 0 {
+		return
+	}
+	dumpBody(req.Header.Get("Content-Type"), body)
+}
+
+func dumpResponse(resp *http.Response, body []byte) {
+	fmt.Fprintf(os.Stderr, "< %s %s\n", resp.Proto, resp.Status)
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			fmt.Fprintf(os.Stderr, "< %s: %s\n", k, v)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "<")
+	dumpBody(resp.Header.Get("Content-Type"), body)
+}
+
+// dumpBody prints a request or response body to stderr, pretty-printing
+// JSON and form-encoded bodies the way dumpFormBody/gron.ColorizeJSON do
+func dumpBody(contentType string, body []byte) {
+	switch {
+	case strings.Contains(contentType, "json"):
+		if c, err := gron.ColorizeJSON(body); err == nil {
+			fmt.Fprintln(os.Stderr, string(c))
+			return
+		}
+		fmt.Fprintln(os.Stderr, string(body))
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		dumpFormBody(body)
+	default:
+		fmt.Fprintln(os.Stderr, string(body))
+	}
+}
+
+func dumpFormBody(body []byte) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, string(body))
+		return
+	}
+	w := bufio.NewWriter(os.Stderr)
+	defer w.Flush()
+	for k, vs := range values {
+		for _, v := range vs {
+			fmt.Fprintf(w, "%s = %s\n", k, v)
+		}
+	}
+}
@@ -1,45 +1,27 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"sort"
+	"time"
 
-	"github.com/fatih/color"
 	isatty "github.com/mattn/go-isatty"
-	"github.com/nwidger/jsoncolor"
-	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/hj3938/gron/internal/httpclient"
+	"github.com/hj3938/gron/pkg/gron"
 )
 
 // Exit codes
 const (
 	exitOK = iota
 	exitOpenFile
-	exitReadInput
-	exitFormStatements
 	exitFetchURL
-	exitParseStatements
-	exitJSONEncode
-)
-
-// Option bitfields
-const (
-	optMonochrome = iota + 1
-	optNoSort
-)
-
-// Output colors
-var (
-	strColor   = color.New(color.FgYellow)
-	braceColor = color.New(color.FgMagenta)
-	bareColor  = color.New(color.FgBlue, color.Bold)
-	numColor   = color.New(color.FgRed)
-	boolColor  = color.New(color.FgCyan)
+	exitEncode
+	exitDecode
+	exitSchemaViolation
 )
 
 // gronVersion stores the current gron version, set at build
@@ -56,17 +38,37 @@ func init() {
 		h += "Options:\n"
 		h += "  -u, --ungron     Reverse the operation (turn assignments back into JSON)\n"
 		h += "  -m, --monochrome Monochrome (don't colorize output)\n"
+		h += "  -s, --stream     Treat the input as a stream of separate JSON values\n"
 		h += "      --no-sort    Don't sort output (faster)\n"
+		h += "      --format     Output format: gron (default), json, jsonl, jsonpath, pointer\n"
+		h += "      --from       Input data format: json (default), yaml, toml, cbor (auto-detected from the file extension)\n"
+		h += "      --to         Output data format for --ungron: json (default), yaml, toml, cbor\n"
 		h += "      --version    Print version information\n\n"
 
+		h += "HTTP Options (only apply when the input is a URL):\n"
+		h += "  -H, --header     Add a header to the request, e.g. -H \"Authorization: token x\" (repeatable)\n"
+		h += "  -X, --method     HTTP method to use (default GET)\n"
+		h += "  -d, --data       Send the given data as the request body; use @file to read it from a file\n"
+		h += "      --user       Send HTTP Basic auth credentials, in user:password form\n"
+		h += "      --bearer     Send the given value as a Bearer token\n"
+		h += "      --netrc      Look up credentials for the request's host in ~/.netrc\n"
+		h += "      --insecure   Disable TLS certificate verification\n"
+		h += "      --timeout    Give up on the request after this long, e.g. 10s (default: no timeout)\n\n"
+
+		h += "Set GRON_DEBUG=1 to dump outgoing requests and incoming responses to stderr.\n\n"
+
+		h += "Validation Options:\n"
+		h += "      --schema     Validate the input against a JSON Schema (draft 2020-12) file or URL before gronning it\n"
+		h += "      --match      Restrict the input to the subtree at this jsonpath expression before gronning it\n"
+		h += "      --prune      Remove the subtree at this jsonpath expression before gronning it\n\n"
+
 		h += "Exit Codes:\n"
 		h += fmt.Sprintf("  %d\t%s\n", exitOK, "OK")
 		h += fmt.Sprintf("  %d\t%s\n", exitOpenFile, "Failed to open file")
-		h += fmt.Sprintf("  %d\t%s\n", exitReadInput, "Failed to read input")
-		h += fmt.Sprintf("  %d\t%s\n", exitFormStatements, "Failed to form statements")
 		h += fmt.Sprintf("  %d\t%s\n", exitFetchURL, "Failed to fetch URL")
-		h += fmt.Sprintf("  %d\t%s\n", exitParseStatements, "Failed to parse statements")
-		h += fmt.Sprintf("  %d\t%s\n", exitJSONEncode, "Failed to encode JSON")
+		h += fmt.Sprintf("  %d\t%s\n", exitEncode, "Failed to gron the input")
+		h += fmt.Sprintf("  %d\t%s\n", exitDecode, "Failed to ungron the input")
+		h += fmt.Sprintf("  %d\t%s\n", exitSchemaViolation, "Input failed schema validation")
 		h += "\n"
 
 		h += "Examples:\n"
@@ -84,7 +86,22 @@ func main() {
 		ungronFlag     bool
 		monochromeFlag bool
 		noSortFlag     bool
+		streamFlag     bool
 		versionFlag    bool
+		formatFlag     string
+		fromFlag       string
+		toFlag         string
+		headerFlag     headerList
+		methodFlag     string
+		dataFlag       string
+		userFlag       string
+		bearerFlag     string
+		netrcFlag      bool
+		insecureFlag   bool
+		timeoutFlag    time.Duration
+		schemaFlag     string
+		matchFlag      string
+		pruneFlag      string
 	)
 
 	flag.BoolVar(&ungronFlag, "ungron", false, "")
@@ -92,10 +109,39 @@ func main() {
 	flag.BoolVar(&monochromeFlag, "monochrome", false, "")
 	flag.BoolVar(&monochromeFlag, "m", false, "")
 	flag.BoolVar(&noSortFlag, "no-sort", false, "")
+	flag.BoolVar(&streamFlag, "stream", false, "")
+	flag.BoolVar(&streamFlag, "s", false, "")
 	flag.BoolVar(&versionFlag, "version", false, "")
+	flag.StringVar(&formatFlag, "format", "", "")
+	flag.StringVar(&fromFlag, "from", "", "")
+	flag.StringVar(&toFlag, "to", "", "")
+	flag.Var(&headerFlag, "header", "")
+	flag.Var(&headerFlag, "H", "")
+	flag.StringVar(&methodFlag, "method", "", "")
+	flag.StringVar(&methodFlag, "X", "", "")
+	flag.StringVar(&dataFlag, "data", "", "")
+	flag.StringVar(&dataFlag, "d", "", "")
+	flag.StringVar(&userFlag, "user", "", "")
+	flag.StringVar(&bearerFlag, "bearer", "", "")
+	flag.BoolVar(&netrcFlag, "netrc", false, "")
+	flag.BoolVar(&insecureFlag, "insecure", false, "")
+	flag.DurationVar(&timeoutFlag, "timeout", 0, "")
+	flag.StringVar(&schemaFlag, "schema", "", "")
+	flag.StringVar(&matchFlag, "match", "", "")
+	flag.StringVar(&pruneFlag, "prune", "", "")
 
 	flag.Parse()
 
+	format, err := gron.ParseFormat(formatFlag)
+	if err != nil {
+		fatal(exitEncode, err)
+	}
+
+	to, err := gron.ParseDataFormat(toFlag)
+	if err != nil {
+		fatal(exitDecode, err)
+	}
+
 	// Print version information
 	if versionFlag {
 		fmt.Printf("gron version %s\n", gronVersion)
@@ -106,6 +152,17 @@ func main() {
 	// file, HTTP URL or stdin
 	var rawInput io.Reader
 	filename := flag.Arg(0)
+
+	var from gron.DataFormat
+	if fromFlag != "" {
+		from, err = gron.ParseDataFormat(fromFlag)
+		if err != nil {
+			fatal(exitEncode, err)
+		}
+	} else if filename != "" && filename != "-" {
+		from = gron.DetectDataFormat(filename)
+	}
+
 	if filename == "" || filename == "-" {
 		rawInput = os.Stdin
 	} else {
@@ -116,7 +173,16 @@ func main() {
 			}
 			rawInput = r
 		} else {
-			r, err := getURL(filename)
+			r, err := httpclient.Get(filename, &httpclient.Options{
+				Headers:  headerFlag,
+				Method:   methodFlag,
+				Data:     dataFlag,
+				User:     userFlag,
+				Bearer:   bearerFlag,
+				Netrc:    netrcFlag,
+				Insecure: insecureFlag,
+				Timeout:  timeoutFlag,
+			})
 			if err != nil {
 				fatal(exitFetchURL, err)
 			}
@@ -124,138 +190,81 @@ func main() {
 		}
 	}
 
-	var opts int
+	var schema *jsonschema.Schema
+	if schemaFlag != "" {
+		schema, err = loadSchema(schemaFlag)
+		if err != nil {
+			fatal(exitEncode, err)
+		}
+	}
+
 	// The monochrome option should be forced if the output isn't a terminal
 	// to avoid doing unnecessary work calling the color functions
-	if monochromeFlag || !isatty.IsTerminal(os.Stdout.Fd()) {
-		opts = opts | optMonochrome
-	}
-	if noSortFlag {
-		opts = opts | optNoSort
+	monochrome := monochromeFlag || !isatty.IsTerminal(os.Stdout.Fd())
+
+	opts := &gron.Options{
+		Monochrome: monochrome,
+		NoSort:     noSortFlag,
+		Stream:     streamFlag,
+		Format:     format,
+		From:       from,
+		To:         to,
+		Schema:     schema,
+		Match:      matchFlag,
+		Prune:      pruneFlag,
 	}
 
-	// Pick the appropriate action: gron or ungron
-	var a actionFn = gron
 	if ungronFlag {
-		a = ungron
-	}
-	exitCode, err := a(rawInput, os.Stdout, opts)
-
-	if exitCode != exitOK {
-		fatal(exitCode, err)
-	}
-
-	os.Exit(exitOK)
-}
-
-// an actionFn represents a main action of the program, it accepts
-// an input, output and a bitfield of options; returning an exit
-// code and any error that occurred
-type actionFn func(io.Reader, io.Writer, int) (int, error)
-
-// gron is the default action. Given JSON as the input it returns a list
-// of assignment statements. Possible options are optNoSort and optMonochrome
-func gron(r io.Reader, w io.Writer, opts int) (int, error) {
-
-	ss, err := statementsFromJSON(r)
-	if err != nil {
-		return exitFormStatements, fmt.Errorf("failed to form statements: %s", err)
-	}
-
-	// Go's maps do not have well-defined ordering, but we want a consistent
-	// output for a given input, so we must sort the statements
-	if opts&optNoSort == 0 {
-		sort.Sort(ss)
-	}
-
-	if opts&optMonochrome > 0 {
-		for _, s := range ss {
-			fmt.Fprintln(w, s.String())
+		if err := gron.Decode(rawInput, os.Stdout, opts); err != nil {
+			fatal(exitDecode, err)
 		}
 	} else {
-		for _, s := range ss {
-			fmt.Fprintln(w, s.colorString())
+		if err := gron.Encode(rawInput, os.Stdout, opts); err != nil {
+			if sv, ok := err.(*gron.ErrSchemaViolation); ok {
+				gron.WriteViolations(os.Stderr, sv.Violations)
+				os.Exit(exitSchemaViolation)
+			}
+			fatal(exitEncode, err)
 		}
 	}
 
-	return exitOK, nil
+	os.Exit(exitOK)
 }
 
-// ungron is the reverse of gron. Given assignment statements as input,
-// it returns JSON. The only option is optMonochrome
-func ungron(r io.Reader, w io.Writer, opts int) (int, error) {
-	scanner := bufio.NewScanner(r)
-
-	// Make a list of statements from the input
-	var ss statements
-	for scanner.Scan() {
-		s := statementFromString(scanner.Text())
-		ss.add(s)
-	}
-	if err := scanner.Err(); err != nil {
-		return exitReadInput, fmt.Errorf("failed to read input statements")
-	}
-
-	// turn the statements into a single merged interface{} type
-	merged, err := ss.toInterface()
-	if err != nil {
-		return exitParseStatements, err
-	}
+func fatal(code int, err error) {
+	fmt.Fprintf(os.Stderr, "%s\n", err)
+	os.Exit(code)
+}
 
-	// If there's only one top level key and it's "json", make that the top level thing
-	mergedMap, ok := merged.(map[string]interface{})
-	if ok {
-		if len(mergedMap) == 1 {
-			if _, exists := mergedMap["json"]; exists {
-				merged = mergedMap["json"]
-			}
+// loadSchema reads and compiles a JSON Schema from a file path or URL
+func loadSchema(fileOrURL string) (*jsonschema.Schema, error) {
+	var r io.Reader
+	if validURL(fileOrURL) {
+		resp, err := httpclient.Get(fileOrURL, nil)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	// Marshal the output into JSON to display to the user
-	j, err := json.MarshalIndent(merged, "", "  ")
-	if err != nil {
-		return exitJSONEncode, errors.Wrap(err, "failed to convert statements to JSON")
-	}
-
-	// If the output isn't monochrome, add color to the JSON
-	if opts&optMonochrome == 0 {
-		c, err := colorizeJSON(j)
-
-		// If we failed to colorize the JSON for whatever reason,
-		// we'll just fall back to monochrome output, otherwise
-		// replace the monochrome JSON with glorious technicolor
-		if err == nil {
-			j = c
+		r = resp
+	} else {
+		f, err := os.Open(fileOrURL)
+		if err != nil {
+			return nil, err
 		}
+		defer f.Close()
+		r = f
 	}
 
-	fmt.Fprintf(w, "%s\n", j)
-
-	return exitOK, nil
+	return gron.CompileSchema(r)
 }
 
-func colorizeJSON(src []byte) ([]byte, error) {
-	out := &bytes.Buffer{}
-	f := jsoncolor.NewFormatter()
+// headerList collects repeated -H/--header flags into a slice
+type headerList []string
 
-	f.StringColor = strColor
-	f.ObjectColor = braceColor
-	f.ArrayColor = braceColor
-	f.FieldColor = bareColor
-	f.NumberColor = numColor
-	f.TrueColor = boolColor
-	f.FalseColor = boolColor
-	f.NullColor = boolColor
-
-	err := f.Format(out, src)
-	if err != nil {
-		return out.Bytes(), err
-	}
-	return out.Bytes(), nil
+func (h *headerList) String() string {
+	return fmt.Sprintf("%v", []string(*h))
 }
 
-func fatal(code int, err error) {
-	fmt.Fprintf(os.Stderr, "%s\n", err)
-	os.Exit(code)
+func (h *headerList) Set(v string) error {
+	*h = append(*h, v)
+	return nil
 }
@@ -0,0 +1,12 @@
+package gron
+
+import "github.com/fatih/color"
+
+// Output colors used when rendering statements for a terminal
+var (
+	strColor   = color.New(color.FgYellow)
+	braceColor = color.New(color.FgMagenta)
+	bareColor  = color.New(color.FgBlue, color.Bold)
+	numColor   = color.New(color.FgRed)
+	boolColor  = color.New(color.FgCyan)
+)
@@ -0,0 +1,263 @@
+package gron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fxamacker/cbor/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DataFormat identifies the serialization of the tree gron flattens
+// into statements (for --from) or rebuilds from them (for --to)
+type DataFormat int
+
+const (
+	DataJSON DataFormat = iota
+	DataYAML
+	DataTOML
+	DataCBOR
+)
+
+// ParseDataFormat turns the value of --from/--to into a DataFormat,
+// defaulting to DataJSON
+func ParseDataFormat(s string) (DataFormat, error) {
+	switch s {
+	case "", "json":
+		return DataJSON, nil
+	case "yaml", "yml":
+		return DataYAML, nil
+	case "toml":
+		return DataTOML, nil
+	case "cbor":
+		return DataCBOR, nil
+	}
+	return DataJSON, fmt.Errorf("unknown data format %q", s)
+}
+
+// DetectDataFormat guesses a DataFormat from a filename's extension,
+// falling back to DataJSON when it isn't recognised
+func DetectDataFormat(filename string) DataFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return DataYAML
+	case ".toml":
+		return DataTOML
+	case ".cbor":
+		return DataCBOR
+	}
+	return DataJSON
+}
+
+// FromValue reads a document in the given DataFormat from r and turns
+// it into a list of statements rooted at "json", the same shape
+// FromJSON produces for plain JSON input
+func FromValue(r io.Reader, df DataFormat) (Statements, error) {
+	v, err := DecodeTree(r, df)
+	if err != nil {
+		return nil, err
+	}
+	return StatementsFromTree(v), nil
+}
+
+// DecodeTree reads a document in the given DataFormat from r and
+// returns it as a tree of Go maps, slices and scalars, without
+// flattening it into statements. This is the hook --schema, --match
+// and --prune operate on before the tree is gronned
+func DecodeTree(r io.Reader, df DataFormat) (interface{}, error) {
+	if df == DataJSON {
+		d := json.NewDecoder(r)
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %s", err)
+		}
+		return v, nil
+	}
+
+	v, err := decodeValue(r, df)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", dataFormatName(df), err)
+	}
+	return v, nil
+}
+
+// StatementsFromTree flattens an already-decoded tree into statements
+// rooted at "json"
+func StatementsFromTree(v interface{}) Statements {
+	var ss Statements
+	makeStatementsFromValue(&ss, []token{{text: "json", typ: typBare}}, v)
+	return ss
+}
+
+// decodeValue decodes r as one of the non-JSON formats; DataJSON is
+// handled directly by DecodeTree
+func decodeValue(r io.Reader, df DataFormat) (interface{}, error) {
+	switch df {
+	case DataYAML:
+		var v interface{}
+		if err := yaml.NewDecoder(r).Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+
+	case DataTOML:
+		var v map[string]interface{}
+		if _, err := toml.NewDecoder(r).Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+
+	case DataCBOR:
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err := cbor.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return stringifyCBORKeys(v)
+	}
+
+	return nil, fmt.Errorf("unsupported data format %v", df)
+}
+
+// stringifyCBORKeys walks a tree decoded from CBOR, converting any
+// map[interface{}]interface{} (produced when a CBOR map has
+// non-string keys) into map[string]interface{} using a deterministic
+// %v rendering of each key, and erroring if two keys collide
+func stringifyCBORKeys(v interface{}) (interface{}, error) {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(vv))
+		for k, child := range vv {
+			key := fmt.Sprintf("%v", k)
+			if _, exists := out[key]; exists {
+				return nil, fmt.Errorf("CBOR map keys collide after stringifying: %q", key)
+			}
+			converted, err := stringifyCBORKeys(child)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = converted
+		}
+		return out, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(vv))
+		for k, child := range vv {
+			converted, err := stringifyCBORKeys(child)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = converted
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(vv))
+		for i, child := range vv {
+			converted, err := stringifyCBORKeys(child)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = converted
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
+
+// EncodeValue marshals v (as produced by Statements.ToInterface) into
+// the given DataFormat and writes it to w
+func EncodeValue(w io.Writer, v interface{}, df DataFormat) error {
+	switch df {
+	case DataYAML:
+		b, err := yaml.Marshal(normalizeNumbers(v))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+
+	case DataTOML:
+		m, ok := normalizeNumbers(v).(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("TOML output requires a top-level object, got %T", v)
+		}
+		buf := &bytes.Buffer{}
+		if err := toml.NewEncoder(buf).Encode(m); err != nil {
+			return err
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+
+	case DataCBOR:
+		b, err := cbor.Marshal(normalizeNumbers(v))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	// json.Number marshals back to its original literal directly, so
+	// plain JSON output needs no normalization
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// normalizeNumbers walks v, replacing any json.Number (produced by
+// statement.interfaceValue to preserve large integer literals) with
+// an int64 or float64, since YAML/TOML/CBOR encoders don't know what
+// to do with a json.Number and would otherwise emit it as a string
+func normalizeNumbers(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			return i
+		}
+		f, _ := vv.Float64()
+		return f
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(vv))
+		for k, child := range vv {
+			out[k] = normalizeNumbers(child)
+		}
+		return out
+
+	case []interface{}:
+		out := make([]interface{}, len(vv))
+		for i, child := range vv {
+			out[i] = normalizeNumbers(child)
+		}
+		return out
+	}
+
+	return v
+}
+
+func dataFormatName(df DataFormat) string {
+	switch df {
+	case DataYAML:
+		return "YAML"
+	case DataTOML:
+		return "TOML"
+	case DataCBOR:
+		return "CBOR"
+	}
+	return "JSON"
+}
@@ -0,0 +1,120 @@
+package gron
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestDataFormatRoundTrip(t *testing.T) {
+	in := `{"a":1,"b":"two","c":[1,2,3]}`
+
+	for _, df := range []DataFormat{DataYAML, DataTOML, DataCBOR} {
+		df := df
+		t.Run(dataFormatName(df), func(t *testing.T) {
+			var gronned bytes.Buffer
+			if err := Encode(strings.NewReader(in), &gronned, &Options{Monochrome: true}); err != nil {
+				t.Fatalf("Encode: %s", err)
+			}
+
+			var out bytes.Buffer
+			if err := Decode(strings.NewReader(gronned.String()), &out, &Options{Monochrome: true, To: df}); err != nil {
+				t.Fatalf("Decode: %s", err)
+			}
+
+			tree, err := DecodeTree(&out, df)
+			if err != nil {
+				t.Fatalf("DecodeTree: %s", err)
+			}
+			got, err := json.Marshal(tree)
+			if err != nil {
+				t.Fatalf("re-marshaling decoded tree: %s", err)
+			}
+
+			assertJSONEqual(t, string(got), in)
+		})
+	}
+}
+
+// TestCBORPreservesAllIntegersAndByteStrings guards against a
+// regression where CBOR's uint64 and byte-string types, which have no
+// equivalent among encoding/json's decoded types, hit no case in
+// makeStatementsFromValue and were silently dropped from the output
+func TestCBORPreservesAllIntegersAndByteStrings(t *testing.T) {
+	doc := map[string]interface{}{
+		"big":   uint64(18446744073709551615),
+		"bytes": []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+	b, err := cbor.Marshal(doc)
+	if err != nil {
+		t.Fatalf("cbor.Marshal: %s", err)
+	}
+
+	var gronned bytes.Buffer
+	if err := Encode(bytes.NewReader(b), &gronned, &Options{Monochrome: true, From: DataCBOR}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	for _, want := range []string{"json.big = 18446744073709551615", `json.bytes = "3q2+7w=="`} {
+		if !strings.Contains(gronned.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, gronned.String())
+		}
+	}
+}
+
+// TestTOMLDatetimeRoundTrip guards against a regression where a TOML
+// datetime was reconstructed as a quoted RFC 3339 string rather than
+// a native TOML datetime when ungronning back to --to=toml
+func TestTOMLDatetimeRoundTrip(t *testing.T) {
+	in := "k = 2021-01-02T03:04:05Z\n"
+
+	var gronned bytes.Buffer
+	if err := Encode(strings.NewReader(in), &gronned, &Options{Monochrome: true, From: DataTOML}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := Decode(strings.NewReader(gronned.String()), &out, &Options{Monochrome: true, To: DataTOML}); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+
+	if strings.Contains(out.String(), `"`) {
+		t.Errorf("datetime was round-tripped as a quoted string, not a native TOML datetime:\n%s", out.String())
+	}
+}
+
+// TestYAMLFloatIntPreservation guards against a regression where a
+// YAML float with no fractional part (e.g. "c: 3.0") was rendered as
+// "json.c = 3", making it indistinguishable from a genuine integer
+func TestYAMLFloatIntPreservation(t *testing.T) {
+	in := "c: 3.0\nd: 3\n"
+
+	var gronned bytes.Buffer
+	if err := Encode(strings.NewReader(in), &gronned, &Options{Monochrome: true, From: DataYAML}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	for _, want := range []string{"json.c = 3.0", "json.d = 3"} {
+		if !strings.Contains(gronned.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, gronned.String())
+		}
+	}
+
+	// assertJSONEqual can't tell 3.0 and 3 apart (both decode to the
+	// same float64), so check the rendered literals directly instead
+	var out bytes.Buffer
+	if err := Decode(strings.NewReader(gronned.String()), &out, &Options{Monochrome: true}); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	for _, want := range []string{`"c": 3.0`, `"d": 3`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `"d": 3.0`) {
+		t.Errorf("integer 3 gained a spurious decimal point:\n%s", out.String())
+	}
+}
@@ -0,0 +1,405 @@
+package gron
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format identifies one of the statement formats gron and ungron know
+// how to produce/consume
+type Format int
+
+const (
+	// FormatGron is the classic gron-assignment syntax, e.g.
+	// json.users[0].name = "Alice"
+	FormatGron Format = iota
+	// FormatJSON renders statements as a single JSON array of
+	// {"path":...,"type":...,"value":...} objects
+	FormatJSON
+	// FormatJSONL renders one such object per line
+	FormatJSONL
+	// FormatJSONPath renders assignments as $.users[0].name = "Alice"
+	FormatJSONPath
+	// FormatPointer renders assignments as RFC 6901 JSON Pointers,
+	// e.g. /users/0/name = "Alice"
+	FormatPointer
+)
+
+// ParseFormat turns the value of --format into a Format, defaulting
+// to FormatGron
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "", "gron":
+		return FormatGron, nil
+	case "json":
+		return FormatJSON, nil
+	case "jsonl":
+		return FormatJSONL, nil
+	case "jsonpath":
+		return FormatJSONPath, nil
+	case "pointer":
+		return FormatPointer, nil
+	}
+	return FormatGron, fmt.Errorf("unknown format %q", s)
+}
+
+// FromReader decodes statements out of r according to f. For
+// FormatGron it also auto-detects jsonl/jsonpath/pointer input by
+// sniffing the first non-empty line, matching the behaviour of the
+// ungron CLI action
+func FromReader(r io.Reader, f Format) (Statements, error) {
+	scanner := bufio.NewScanner(r)
+
+	var ss Statements
+	detected := f
+	sniffed := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !sniffed && strings.TrimSpace(line) != "" {
+			if f == FormatGron {
+				detected = sniffFormat(line)
+			}
+			sniffed = true
+		}
+
+		s, err := statementFromFormatted(line, detected)
+		if err != nil {
+			return nil, err
+		}
+		ss.Add(s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input statements")
+	}
+
+	return ss, nil
+}
+
+// pathKeys returns the statement's path with the leading "json" root
+// token stripped, since none of the structured formats expose it
+func pathKeys(s statement) []token {
+	if len(s.path) > 0 && s.path[0].typ == typBare && s.path[0].text == "json" {
+		return s.path[1:]
+	}
+	return s.path
+}
+
+// jsonLine renders a statement as a single-line JSON object of the
+// form {"path":["users",0,"name"],"type":"string","value":"Alice"}
+func (s statement) jsonLine() (string, error) {
+	path, rawValue, err := s.jsonLineParts()
+	if err != nil {
+		return "", err
+	}
+
+	line := struct {
+		Path  []interface{}   `json:"path"`
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}{
+		Path:  path,
+		Type:  valueTypName(s.typ),
+		Value: rawValue,
+	}
+
+	b, err := json.Marshal(line)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// jsonLineParts returns a statement's path and value in the shape
+// FormatJSONL/FormatJSON need. The value is carried as a
+// json.RawMessage rather than unmarshaled into interface{}, since
+// round-tripping a number through interface{} forces it through
+// float64 and silently truncates integers wider than 2^53
+func (s statement) jsonLineParts() ([]interface{}, json.RawMessage, error) {
+	var path []interface{}
+	for _, t := range pathKeys(s) {
+		if t.typ == typIndex {
+			n, _ := strconv.Atoi(t.text)
+			path = append(path, n)
+		} else {
+			path = append(path, t.text)
+		}
+	}
+
+	// typDatetime's value isn't valid JSON on its own (it's a bare
+	// RFC 3339 literal, not a quoted string, so that it round-trips
+	// through plain gron text distinguishably from typString); quote
+	// it into a proper JSON string instead of passing it through raw
+	if s.typ == typDatetime {
+		b, err := json.Marshal(s.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return path, json.RawMessage(b), nil
+	}
+
+	if !json.Valid([]byte(s.value)) {
+		return nil, nil, fmt.Errorf("invalid value: %s", s.value)
+	}
+	return path, json.RawMessage(s.value), nil
+}
+
+// jsonPath renders a statement as a jsonpath assignment, e.g.
+// $.users[0].name = "Alice"
+func (s statement) jsonPath() string {
+	buf := bytes.Buffer{}
+	buf.WriteString("$")
+	for _, t := range pathKeys(s) {
+		switch t.typ {
+		case typBare:
+			buf.WriteString(".")
+			buf.WriteString(t.text)
+		case typQuotedKey:
+			buf.WriteString("[")
+			buf.WriteString(strconv.Quote(t.text))
+			buf.WriteString("]")
+		case typIndex:
+			buf.WriteString("[")
+			buf.WriteString(t.text)
+			buf.WriteString("]")
+		}
+	}
+	buf.WriteString(" = ")
+	buf.WriteString(s.value)
+	return buf.String()
+}
+
+// pointer renders a statement's path as an RFC 6901 JSON Pointer
+// assignment, e.g. /users/0/name = "Alice"
+func (s statement) pointer() string {
+	buf := bytes.Buffer{}
+	for _, t := range pathKeys(s) {
+		buf.WriteString("/")
+		buf.WriteString(pointerEscape(t.text))
+	}
+	buf.WriteString(" = ")
+	buf.WriteString(s.value)
+	return buf.String()
+}
+
+func pointerEscape(s string) string {
+	s = strings.ReplaceAll(s, "~", "~0")
+	s = strings.ReplaceAll(s, "/", "~1")
+	return s
+}
+
+func pointerUnescape(s string) string {
+	s = strings.ReplaceAll(s, "~1", "/")
+	s = strings.ReplaceAll(s, "~0", "~")
+	return s
+}
+
+func valueTypName(t valueTyp) string {
+	switch t {
+	case typString:
+		return "string"
+	case typNumber:
+		return "number"
+	case typBool:
+		return "bool"
+	case typNull:
+		return "null"
+	case typEmptyObject:
+		return "object"
+	case typEmptyArray:
+		return "array"
+	case typDatetime:
+		return "datetime"
+	}
+	return "unknown"
+}
+
+func valueTypFromName(name string) (valueTyp, bool) {
+	switch name {
+	case "string":
+		return typString, true
+	case "number":
+		return typNumber, true
+	case "bool":
+		return typBool, true
+	case "null":
+		return typNull, true
+	case "object":
+		return typEmptyObject, true
+	case "array":
+		return typEmptyArray, true
+	case "datetime":
+		return typDatetime, true
+	}
+	return 0, false
+}
+
+// writeStatementsFormatted writes ss to w in the given format,
+// colorizing the legacy gron format unless monochrome is set.
+// FormatJSON is handled separately by writeJSONArray, since it
+// produces a single value rather than one line per statement
+func writeStatementsFormatted(w io.Writer, ss Statements, monochrome bool, f Format) error {
+	for _, s := range ss {
+		var line string
+		var err error
+
+		switch f {
+		case FormatJSONL:
+			line, err = s.jsonLine()
+		case FormatJSONPath:
+			line = s.jsonPath()
+		case FormatPointer:
+			line = s.pointer()
+		default:
+			if monochrome {
+				line = s.String()
+			} else {
+				line = s.colorString()
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+// writeJSONArray writes ss as a single JSON array of
+// {"path":...,"type":...,"value":...} objects, for FormatJSON
+func writeJSONArray(w io.Writer, ss Statements) error {
+	type entry struct {
+		Path  []interface{}   `json:"path"`
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+
+	entries := make([]entry, 0, len(ss))
+	for _, s := range ss {
+		path, rawValue, err := s.jsonLineParts()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{Path: path, Type: valueTypName(s.typ), Value: rawValue})
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// statementFromFormatted parses a single line produced by one of the
+// structured formats back into a statement
+func statementFromFormatted(line string, f Format) (statement, error) {
+	switch f {
+	case FormatJSONL:
+		return statementFromJSONLine(line)
+	case FormatJSONPath:
+		return statementFromJSONPathLine(line)
+	case FormatPointer:
+		return statementFromPointerLine(line)
+	}
+	return statementFromString(line), nil
+}
+
+// sniffFormat looks at a line of input and decides which structured
+// format, if any, it was produced in
+func sniffFormat(line string) Format {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return FormatJSONL
+	case strings.HasPrefix(line, "$"):
+		return FormatJSONPath
+	case strings.HasPrefix(line, "/"):
+		return FormatPointer
+	}
+	return FormatGron
+}
+
+func statementFromJSONLine(line string) (statement, error) {
+	var parsed struct {
+		Path  []interface{}   `json:"path"`
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+		return statement{}, err
+	}
+
+	path := []token{{text: "json", typ: typBare}}
+	for _, p := range parsed.Path {
+		switch v := p.(type) {
+		case float64:
+			path = append(path, token{text: strconv.Itoa(int(v)), typ: typIndex})
+		case string:
+			path = append(path, keyToken(v))
+		}
+	}
+
+	// The "type" field disambiguates typDatetime from typString, which
+	// both marshal to a quoted JSON string in the "value" field
+	if typ, ok := valueTypFromName(parsed.Type); ok && typ == typDatetime {
+		var value string
+		if err := json.Unmarshal(parsed.Value, &value); err != nil {
+			return statement{}, err
+		}
+		return statement{path: path, value: value, typ: typDatetime}, nil
+	}
+
+	return statement{path: path, value: string(parsed.Value), typ: typOfValue(string(parsed.Value))}, nil
+}
+
+func statementFromJSONPathLine(line string) (statement, error) {
+	parts := strings.SplitN(line, " = ", 2)
+	if len(parts) != 2 {
+		return statement{}, fmt.Errorf("invalid jsonpath statement: %s", line)
+	}
+	rest := strings.TrimPrefix(strings.TrimSpace(parts[0]), "$")
+	path := append([]token{{text: "json", typ: typBare}}, parsePath(rest)...)
+	value := strings.TrimSpace(parts[1])
+	return statement{path: path, value: value, typ: typOfValue(value)}, nil
+}
+
+func statementFromPointerLine(line string) (statement, error) {
+	parts := strings.SplitN(line, " = ", 2)
+	if len(parts) != 2 {
+		return statement{}, fmt.Errorf("invalid pointer statement: %s", line)
+	}
+	value := strings.TrimSpace(parts[1])
+
+	path := []token{{text: "json", typ: typBare}}
+	for _, seg := range strings.Split(strings.TrimPrefix(parts[0], "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = pointerUnescape(seg)
+		if isIndex(seg) {
+			path = append(path, token{text: seg, typ: typIndex})
+		} else {
+			path = append(path, keyToken(seg))
+		}
+	}
+
+	return statement{path: path, value: value, typ: typOfValue(value)}, nil
+}
+
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package gron
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	in := `{"a":1,"b":[true,null],"c":"x y"}`
+
+	for _, f := range []Format{FormatJSONL, FormatJSONPath, FormatPointer} {
+		f := f
+		t.Run(fmt.Sprintf("format=%d", f), func(t *testing.T) {
+			var encoded bytes.Buffer
+			if err := Encode(strings.NewReader(in), &encoded, &Options{Monochrome: true, Format: f}); err != nil {
+				t.Fatalf("Encode: %s", err)
+			}
+
+			// Decode is left at the zero-value Format (FormatGron) here
+			// on purpose: ungron is supposed to auto-detect jsonl,
+			// jsonpath and pointer input by sniffing the first line
+			var out bytes.Buffer
+			if err := Decode(strings.NewReader(encoded.String()), &out, &Options{Monochrome: true}); err != nil {
+				t.Fatalf("Decode: %s", err)
+			}
+
+			assertJSONEqual(t, out.String(), in)
+		})
+	}
+}
+
+// TestJSONLPreservesLargeIntegers guards against a regression where
+// jsonLineParts unmarshaled a statement's numeric value into
+// interface{}, forcing it through float64 and corrupting integers
+// wider than 2^53
+func TestJSONLPreservesLargeIntegers(t *testing.T) {
+	in := `{"id":9007199254740993,"big":12345678901234567890}`
+
+	var jsonl bytes.Buffer
+	if err := Encode(strings.NewReader(in), &jsonl, &Options{Monochrome: true, Format: FormatJSONL}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	for _, want := range []string{`"value":9007199254740993`, `"value":12345678901234567890`} {
+		if !strings.Contains(jsonl.String(), want) {
+			t.Errorf("jsonl output missing %q:\n%s", want, jsonl.String())
+		}
+	}
+
+	var arr bytes.Buffer
+	if err := Encode(strings.NewReader(in), &arr, &Options{Monochrome: true, Format: FormatJSON}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	for _, want := range []string{"9007199254740993", "12345678901234567890"} {
+		if !strings.Contains(arr.String(), want) {
+			t.Errorf("json output missing %q:\n%s", want, arr.String())
+		}
+	}
+}
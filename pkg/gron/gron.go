@@ -0,0 +1,282 @@
+// Package gron implements the flatten-to-assignments transformation
+// used by the gron command line tool, as a reusable library: Encode
+// turns JSON into greppable statements, Decode reverses the process.
+package gron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/nwidger/jsoncolor"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Options controls the behaviour of Encode and Decode
+type Options struct {
+	// Monochrome disables colorized output
+	Monochrome bool
+	// NoSort skips sorting statements, which is faster but means
+	// output order depends on Go's randomized map iteration
+	NoSort bool
+	// Stream treats the input to Encode as a sequence of
+	// whitespace/newline separated JSON values rather than a
+	// single top-level document
+	Stream bool
+	// Format selects the statement syntax read or written.
+	// Decode auto-detects FormatJSONL/FormatJSONPath/FormatPointer
+	// input even when Format is left as the zero value (FormatGron)
+	Format Format
+	// From selects the serialization Encode parses its input as.
+	// Stream is only supported for DataJSON
+	From DataFormat
+	// To selects the serialization Decode writes its output as. When
+	// Stream is set and Decode splits its input into one document per
+	// json[N] index, those documents are always written as JSON
+	To DataFormat
+	// Schema, when set, validates the decoded document before it's
+	// gronned. Encode returns an *ErrSchemaViolation if it doesn't
+	// conform; Stream is incompatible with Schema, since each
+	// streamed value would need its own (possibly differing) checks
+	Schema *jsonschema.Schema
+	// Match, when set, restricts the document to the single subtree
+	// at the given jsonpath expression before it's gronned
+	Match string
+	// Prune, when set, removes the subtree at the given jsonpath
+	// expression from the document before it's gronned
+	Prune string
+}
+
+// Encode reads a document from r and writes gron statements to w
+func Encode(r io.Reader, w io.Writer, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	if opts.Stream {
+		return encodeStream(r, w, opts)
+	}
+
+	tree, err := DecodeTree(r, opts.From)
+	if err != nil {
+		return err
+	}
+
+	if opts.Schema != nil {
+		if err := ValidateSchema(tree, opts.Schema); err != nil {
+			return err
+		}
+	}
+
+	tree, err = applyMatchAndPrune(tree, opts)
+	if err != nil {
+		return err
+	}
+
+	ss := StatementsFromTree(tree)
+
+	if !opts.NoSort {
+		ss.Sort()
+	}
+
+	return writeEncoded(w, ss, opts)
+}
+
+// applyMatchAndPrune narrows tree down to opts.Match, if set, then
+// removes opts.Prune from what's left
+func applyMatchAndPrune(tree interface{}, opts *Options) (interface{}, error) {
+	if opts.Match != "" {
+		matched, err := Match(tree, opts.Match)
+		if err != nil {
+			return nil, err
+		}
+		tree = matched
+	}
+	if opts.Prune != "" {
+		pruned, err := Prune(tree, opts.Prune)
+		if err != nil {
+			return nil, err
+		}
+		tree = pruned
+	}
+	return tree, nil
+}
+
+// encodeStream is the streaming half of Encode, decoding one JSON
+// value at a time and writing its statements before moving on to the
+// next, so arbitrarily large input never needs to be buffered
+func encodeStream(r io.Reader, w io.Writer, opts *Options) error {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+
+	for i := 0; ; i++ {
+		root := []token{{text: "json", typ: typBare}, {text: fmt.Sprintf("%d", i), typ: typIndex}}
+		ss, err := statementsFromDecoder(d, root)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to form statements: %s", err)
+		}
+
+		if !opts.NoSort {
+			ss.Sort()
+		}
+
+		if err := writeEncoded(w, ss, opts); err != nil {
+			return err
+		}
+	}
+}
+
+func writeEncoded(w io.Writer, ss Statements, opts *Options) error {
+	if opts.Format == FormatJSON {
+		return writeJSONArray(w, ss)
+	}
+	return writeStatementsFormatted(w, ss, opts.Monochrome, opts.Format)
+}
+
+// Decode reads gron statements from r and writes the reconstructed
+// document to w, in the serialization selected by opts.To
+func Decode(r io.Reader, w io.Writer, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	ss, err := FromReader(r, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	// Statements produced by Encode with Stream set are prefixed with
+	// json[N] rather than json; in that case emit one JSON document
+	// per index instead of merging everything into a single object.
+	// This only applies when --stream was requested: a top-level JSON
+	// array also gronned to json[N] paths, and without gating on
+	// opts.Stream it would be indistinguishable from a one-document
+	// stream and wrongly split back into separate documents
+	if opts.Stream {
+		if streamed, indices := streamedIndices(ss); streamed {
+			return decodeStream(w, ss, indices, opts)
+		}
+	}
+
+	merged, err := ss.ToInterface()
+	if err != nil {
+		return err
+	}
+
+	// If there's only one top level key and it's "json", make that the top level thing
+	mergedMap, ok := merged.(map[string]interface{})
+	if ok {
+		if len(mergedMap) == 1 {
+			if _, exists := mergedMap["json"]; exists {
+				merged = mergedMap["json"]
+			}
+		}
+	}
+
+	if opts.To != DataJSON {
+		return EncodeValue(w, merged, opts.To)
+	}
+
+	j, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to convert statements to JSON")
+	}
+
+	if !opts.Monochrome {
+		if c, err := ColorizeJSON(j); err == nil {
+			j = c
+		}
+	}
+
+	fmt.Fprintf(w, "%s\n", j)
+
+	return nil
+}
+
+// streamedIndices reports whether every statement's path begins with
+// json[N] (the prefix used when Stream is set), and if so returns the
+// distinct indices found, in the order they first appear
+func streamedIndices(ss Statements) (bool, []string) {
+	if len(ss) == 0 {
+		return false, nil
+	}
+
+	seen := map[string]bool{}
+	var indices []string
+	for _, s := range ss {
+		if len(s.path) < 2 || s.path[0].text != "json" || s.path[0].typ != typBare || s.path[1].typ != typIndex {
+			return false, nil
+		}
+		idx := s.path[1].text
+		if !seen[idx] {
+			seen[idx] = true
+			indices = append(indices, idx)
+		}
+	}
+
+	return true, indices
+}
+
+// decodeStream rebuilds one JSON document per json[N] index found in
+// ss, writing them newline-separated rather than merging them into a
+// single value
+func decodeStream(w io.Writer, ss Statements, indices []string, opts *Options) error {
+	for _, idx := range indices {
+		var docStatements Statements
+		for _, s := range ss {
+			if s.path[1].text != idx {
+				continue
+			}
+			doc := s
+			doc.path = s.path[2:]
+			docStatements.Add(doc)
+		}
+
+		merged, err := docStatements.ToInterface()
+		if err != nil {
+			return err
+		}
+
+		j, err := json.Marshal(merged)
+		if err != nil {
+			return errors.Wrap(err, "failed to convert statements to JSON")
+		}
+
+		if !opts.Monochrome {
+			if c, err := ColorizeJSON(j); err == nil {
+				j = c
+			}
+		}
+
+		fmt.Fprintf(w, "%s\n", j)
+	}
+
+	return nil
+}
+
+// ColorizeJSON adds ANSI color codes to already-marshaled JSON, for
+// display on a terminal
+func ColorizeJSON(src []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	f := jsoncolor.NewFormatter()
+
+	f.StringColor = strColor
+	f.ObjectColor = braceColor
+	f.ArrayColor = braceColor
+	f.FieldColor = bareColor
+	f.NumberColor = numColor
+	f.TrueColor = boolColor
+	f.FalseColor = boolColor
+	f.NullColor = boolColor
+
+	err := f.Format(out, src)
+	if err != nil {
+		return out.Bytes(), err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+package gron
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromJSON reads a single JSON document from r and turns it into a
+// list of statements rooted at "json"
+func FromJSON(r io.Reader) (Statements, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return statementsFromDecoder(d, []token{{text: "json", typ: typBare}})
+}
+
+// statementsFromDecoder decodes a single JSON value from d and turns
+// it into a list of statements rooted at rootPath. It's shared by the
+// single-document and streaming code paths
+func statementsFromDecoder(d *json.Decoder, rootPath []token) (Statements, error) {
+	var top interface{}
+	if err := d.Decode(&top); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to parse JSON: %s", err)
+	}
+
+	var ss Statements
+	makeStatementsFromValue(&ss, rootPath, top)
+
+	return ss, nil
+}
+
+// makeStatementsFromValue recursively walks v, appending one
+// statement per leaf (and one for each empty object/array) to ss
+func makeStatementsFromValue(ss *Statements, path []token, v interface{}) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if len(vv) == 0 {
+			ss.Add(statement{path: path, value: "{}", typ: typEmptyObject})
+			return
+		}
+		for k, child := range vv {
+			childPath := append(append([]token{}, path...), keyToken(k))
+			makeStatementsFromValue(ss, childPath, child)
+		}
+
+	case []interface{}:
+		if len(vv) == 0 {
+			ss.Add(statement{path: path, value: "[]", typ: typEmptyArray})
+			return
+		}
+		for i, child := range vv {
+			childPath := append(append([]token{}, path...), token{text: fmt.Sprintf("%d", i), typ: typIndex})
+			makeStatementsFromValue(ss, childPath, child)
+		}
+
+	case json.Number:
+		ss.Add(statement{path: path, value: vv.String(), typ: typNumber})
+
+	// int, int64 and float64 show up when the tree was decoded from
+	// YAML, TOML or CBOR rather than encoding/json
+	case int:
+		ss.Add(statement{path: path, value: strconv.Itoa(vv), typ: typNumber})
+
+	case int64:
+		ss.Add(statement{path: path, value: strconv.FormatInt(vv, 10), typ: typNumber})
+
+	case float64:
+		ss.Add(statement{path: path, value: formatFloat(vv), typ: typNumber})
+
+	// uint64 shows up when CBOR decodes a non-negative integer, which
+	// it always does as uint64 whatever its size
+	case uint64:
+		ss.Add(statement{path: path, value: strconv.FormatUint(vv, 10), typ: typNumber})
+
+	case string:
+		b, _ := json.Marshal(vv)
+		ss.Add(statement{path: path, value: string(b), typ: typString})
+
+	// []byte is CBOR's byte string type, which has no JSON
+	// equivalent; we round-trip it as a base64-encoded string
+	case []byte:
+		b, _ := json.Marshal(base64.StdEncoding.EncodeToString(vv))
+		ss.Add(statement{path: path, value: string(b), typ: typString})
+
+	case bool:
+		ss.Add(statement{path: path, value: fmt.Sprintf("%t", vv), typ: typBool})
+
+	// time.Time is TOML's native datetime type, which has no JSON
+	// equivalent; we round-trip it as a bare RFC 3339 literal (rather
+	// than a quoted string) so it stays distinguishable from
+	// typString once it's been through plain gron text
+	case time.Time:
+		ss.Add(statement{path: path, value: vv.Format(time.RFC3339Nano), typ: typDatetime})
+
+	case nil:
+		ss.Add(statement{path: path, value: "null", typ: typNull})
+	}
+}
+
+// formatFloat renders f the way strconv would, except an integral
+// value (e.g. YAML's "3.0") keeps a visible decimal point instead of
+// becoming indistinguishable from a genuine integer like "3" - that
+// distinction is what lets a YAML float round-trip as a float
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// keyToken returns the appropriate token for a map key, quoting it
+// if it isn't a valid bare identifier
+func keyToken(k string) token {
+	if isBareKey(k) {
+		return token{text: k, typ: typBare}
+	}
+	return token{text: k, typ: typQuotedKey}
+}
+
+func isBareKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for i, c := range k {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		isDigit := c >= '0' && c <= '9'
+		if i == 0 && !isLetter {
+			return false
+		}
+		if !isLetter && !isDigit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package gron
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Match restricts v to the single subtree located at the given
+// jsonpath expression (e.g. "$.users[0]" or "users[0]"), returning
+// nil if no value exists at that path
+func Match(v interface{}, jsonpath string) (interface{}, error) {
+	path := parsePath(strings.TrimPrefix(strings.TrimSpace(jsonpath), "$"))
+	return walkGet(v, path)
+}
+
+func walkGet(v interface{}, path []token) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	head, rest := path[0], path[1:]
+
+	switch head.typ {
+	case typBare, typQuotedKey:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		return walkGet(m[head.text], rest)
+
+	case typIndex:
+		s, ok := v.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		idx, err := strconv.Atoi(head.text)
+		if err != nil || idx < 0 || idx >= len(s) {
+			return nil, nil
+		}
+		return walkGet(s[idx], rest)
+	}
+
+	return nil, fmtPathError(path)
+}
+
+// Prune removes the subtree at the given jsonpath expression from v,
+// returning the (possibly modified) top-level value
+func Prune(v interface{}, jsonpath string) (interface{}, error) {
+	path := parsePath(strings.TrimPrefix(strings.TrimSpace(jsonpath), "$"))
+	if len(path) == 0 {
+		return nil, nil
+	}
+	return walkPrune(v, path)
+}
+
+func walkPrune(v interface{}, path []token) (interface{}, error) {
+	head, rest := path[0], path[1:]
+
+	switch head.typ {
+	case typBare, typQuotedKey:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+		if len(rest) == 0 {
+			delete(m, head.text)
+			return m, nil
+		}
+		child, err := walkPrune(m[head.text], rest)
+		if err != nil {
+			return nil, err
+		}
+		m[head.text] = child
+		return m, nil
+
+	case typIndex:
+		s, ok := v.([]interface{})
+		if !ok {
+			return v, nil
+		}
+		idx, err := strconv.Atoi(head.text)
+		if err != nil || idx < 0 || idx >= len(s) {
+			return v, nil
+		}
+		if len(rest) == 0 {
+			return append(s[:idx], s[idx+1:]...), nil
+		}
+		child, err := walkPrune(s[idx], rest)
+		if err != nil {
+			return nil, err
+		}
+		s[idx] = child
+		return s, nil
+	}
+
+	return v, fmtPathError(path)
+}
@@ -0,0 +1,123 @@
+package gron
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaViolation describes one way a document failed to validate
+// against a JSON Schema
+type SchemaViolation struct {
+	Path    []token
+	Value   interface{}
+	Message string
+}
+
+// ErrSchemaViolation is returned by ValidateSchema when the document
+// doesn't conform to the schema; callers can type-assert on it to
+// pick a distinct exit code
+type ErrSchemaViolation struct {
+	Violations []SchemaViolation
+}
+
+func (e *ErrSchemaViolation) Error() string {
+	return fmt.Sprintf("document violates schema: %d violation(s)", len(e.Violations))
+}
+
+// CompileSchema compiles a draft 2020-12 JSON Schema read from r. Format
+// keywords (e.g. "email", "date-time") are asserted rather than treated
+// as mere annotations, since --schema is meant to reject non-conforming
+// documents
+func CompileSchema(r io.Reader) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	c.AssertFormat = true
+	if err := c.AddResource("schema.json", r); err != nil {
+		return nil, fmt.Errorf("failed to load schema: %s", err)
+	}
+	return c.Compile("schema.json")
+}
+
+// ValidateSchema validates v against sch, returning *ErrSchemaViolation
+// if it doesn't conform
+func ValidateSchema(v interface{}, sch *jsonschema.Schema) error {
+	err := sch.Validate(v)
+	if err == nil {
+		return nil
+	}
+
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	return &ErrSchemaViolation{Violations: violationsFromError(v, ve)}
+}
+
+// violationsFromError flattens a jsonschema.ValidationError's cause
+// tree into one SchemaViolation per leaf failure, looking each
+// failure's offending value back up in v by its instance location
+func violationsFromError(v interface{}, ve *jsonschema.ValidationError) []SchemaViolation {
+	var out []SchemaViolation
+
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			path := pathFromInstanceLocation(e.InstanceLocation)
+			// path always has a leading "json" token; walkGet expects
+			// a path relative to v itself
+			instance, _ := walkGet(v, path[1:])
+			out = append(out, SchemaViolation{
+				Path:    path,
+				Value:   instance,
+				Message: e.Message,
+			})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+
+	return out
+}
+
+// pathFromInstanceLocation turns an RFC 6901 JSON Pointer, as used by
+// jsonschema.ValidationError.InstanceLocation (e.g. "/users/3/email"),
+// into a path rooted at "json"
+func pathFromInstanceLocation(loc string) []token {
+	path := []token{{text: "json", typ: typBare}}
+	for _, seg := range strings.Split(strings.TrimPrefix(loc, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = pointerUnescape(seg)
+		if isIndex(seg) {
+			path = append(path, token{text: seg, typ: typIndex})
+		} else {
+			path = append(path, keyToken(seg))
+		}
+	}
+	return path
+}
+
+// WriteViolations writes one gron-style comment-assignment line per
+// violation to w, of the form
+// `<path> = /* schema: <message> */ <value>`, where <message> is the
+// validator's own error message and <value> is the offending value
+// marshaled as JSON
+func WriteViolations(w io.Writer, violations []SchemaViolation) {
+	for _, v := range violations {
+		s := statement{path: v.Path}
+		value, err := json.Marshal(v.Value)
+		if err != nil {
+			value = []byte("null")
+		}
+		fmt.Fprintf(w, "%s = /* schema: %s */ %s\n", s.pathString(), v.Message, value)
+	}
+}
@@ -0,0 +1,144 @@
+package gron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// valueTyp represents the type of the value at the end of a statement
+type valueTyp int
+
+const (
+	typString valueTyp = iota
+	typNumber
+	typBool
+	typNull
+	typEmptyObject
+	typEmptyArray
+	// typDatetime is produced by TOML input, which has a native
+	// datetime type with no JSON equivalent; it round-trips as a bare
+	// (unquoted) RFC 3339 literal
+	typDatetime
+)
+
+// tokenTyp represents the kind of a single path segment in a statement
+type tokenTyp int
+
+const (
+	typBare      tokenTyp = iota // .foo
+	typQuotedKey                 // ["foo bar"]
+	typIndex                     // [0]
+)
+
+// a token is a single segment of a statement's path
+type token struct {
+	text string
+	typ  tokenTyp
+}
+
+// a statement is an assignment of a value to a path, e.g.
+// json.users[0].name = "Alice"
+type statement struct {
+	path  []token
+	value string
+	typ   valueTyp
+}
+
+// pathString renders just the path portion of a statement, e.g.
+// json.users[0].name
+func (s statement) pathString() string {
+	buf := bytes.Buffer{}
+	for i, t := range s.path {
+		switch t.typ {
+		case typBare:
+			if i > 0 {
+				buf.WriteString(".")
+			}
+			buf.WriteString(t.text)
+		case typQuotedKey:
+			buf.WriteString("[")
+			buf.WriteString(strconv.Quote(t.text))
+			buf.WriteString("]")
+		case typIndex:
+			buf.WriteString("[")
+			buf.WriteString(t.text)
+			buf.WriteString("]")
+		}
+	}
+	return buf.String()
+}
+
+// String returns the plain-text gron form of a statement
+func (s statement) String() string {
+	return s.pathString() + " = " + s.value
+}
+
+// colorString is the same as String but with the various parts
+// colorized for terminal output
+func (s statement) colorString() string {
+	buf := bytes.Buffer{}
+	for i, t := range s.path {
+		switch t.typ {
+		case typBare:
+			if i > 0 {
+				buf.WriteString(".")
+			}
+			buf.WriteString(bareColor.Sprint(t.text))
+		case typQuotedKey:
+			buf.WriteString(braceColor.Sprint("["))
+			buf.WriteString(strColor.Sprint(strconv.Quote(t.text)))
+			buf.WriteString(braceColor.Sprint("]"))
+		case typIndex:
+			buf.WriteString(braceColor.Sprint("["))
+			buf.WriteString(numColor.Sprint(t.text))
+			buf.WriteString(braceColor.Sprint("]"))
+		}
+	}
+	buf.WriteString(" = ")
+
+	switch s.typ {
+	case typString, typDatetime:
+		buf.WriteString(strColor.Sprint(s.value))
+	case typNumber:
+		buf.WriteString(numColor.Sprint(s.value))
+	case typBool:
+		buf.WriteString(boolColor.Sprint(s.value))
+	case typNull:
+		buf.WriteString(boolColor.Sprint(s.value))
+	default:
+		buf.WriteString(braceColor.Sprint(s.value))
+	}
+	return buf.String()
+}
+
+// interfaceValue decodes s.value into the Go value it represents. A
+// typDatetime statement is reconstituted as a time.Time rather than a
+// plain string, so that a TOML datetime round-trips as a native TOML
+// datetime instead of a quoted string. A typNumber statement is
+// decoded with UseNumber so that it comes back as a json.Number
+// carrying the original literal, rather than a float64 that would
+// corrupt integers wider than 2^53
+func (s statement) interfaceValue() (interface{}, error) {
+	if s.typ == typDatetime {
+		t, err := time.Parse(time.RFC3339Nano, s.value)
+		if err != nil {
+			return nil, fmtPathError(s.path)
+		}
+		return t, nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader([]byte(s.value)))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, fmtPathError(s.path)
+	}
+	return v, nil
+}
+
+func fmtPathError(path []token) error {
+	return fmt.Errorf("invalid path: %v", path)
+}
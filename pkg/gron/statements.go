@@ -0,0 +1,95 @@
+package gron
+
+import (
+	"sort"
+)
+
+// Statements is a collection of gron assignment statements, decoded
+// from or destined for JSON (or one of the other supported formats)
+type Statements []statement
+
+func (ss Statements) Len() int      { return len(ss) }
+func (ss Statements) Swap(i, j int) { ss[i], ss[j] = ss[j], ss[i] }
+func (ss Statements) Less(i, j int) bool {
+	return ss[i].String() < ss[j].String()
+}
+
+// Sort orders the statements so that a given input always produces
+// the same output, since Go's map iteration order is randomized
+func (ss Statements) Sort() {
+	sort.Sort(ss)
+}
+
+// Add appends a statement to the list
+func (ss *Statements) Add(s statement) {
+	*ss = append(*ss, s)
+}
+
+// ToInterface merges the statements back into a single interface{}
+// value, suitable for passing to json.Marshal
+func (ss Statements) ToInterface() (interface{}, error) {
+	var top interface{}
+
+	for _, s := range ss {
+		v, err := s.interfaceValue()
+		if err != nil {
+			return nil, err
+		}
+
+		top, err = assignPath(top, s.path, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return top, nil
+}
+
+// assignPath sets v at the given path within top, growing maps and
+// slices as needed, and returns the (possibly new) top-level value
+func assignPath(top interface{}, path []token, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	head, rest := path[0], path[1:]
+
+	switch head.typ {
+	case typBare, typQuotedKey:
+		m, ok := top.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		child, err := assignPath(m[head.text], rest, v)
+		if err != nil {
+			return nil, err
+		}
+		m[head.text] = child
+		return m, nil
+
+	case typIndex:
+		idx := 0
+		for _, c := range head.text {
+			if c < '0' || c > '9' {
+				return nil, fmtPathError(path)
+			}
+			idx = idx*10 + int(c-'0')
+		}
+
+		s, ok := top.([]interface{})
+		if !ok {
+			s = []interface{}{}
+		}
+		for len(s) <= idx {
+			s = append(s, nil)
+		}
+		child, err := assignPath(s[idx], rest, v)
+		if err != nil {
+			return nil, err
+		}
+		s[idx] = child
+		return s, nil
+	}
+
+	return nil, fmtPathError(path)
+}
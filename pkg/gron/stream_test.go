@@ -0,0 +1,78 @@
+package gron
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// assertJSONEqual compares got and want as JSON documents rather than
+// as strings, so differences in whitespace or key order don't fail
+// the test
+func assertJSONEqual(t *testing.T, got, want string) {
+	t.Helper()
+
+	var gotVal, wantVal interface{}
+	if err := json.Unmarshal([]byte(got), &gotVal); err != nil {
+		t.Fatalf("got isn't valid JSON: %s\n%s", err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &wantVal); err != nil {
+		t.Fatalf("want isn't valid JSON: %s\n%s", err, want)
+	}
+
+	if !reflect.DeepEqual(gotVal, wantVal) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// assertJSONDocsEqual is assertJSONEqual for the newline-separated
+// documents that --stream produces
+func assertJSONDocsEqual(t *testing.T, got string, want []string) {
+	t.Helper()
+
+	gotDocs := strings.Fields(strings.TrimSpace(got))
+	if len(gotDocs) != len(want) {
+		t.Fatalf("got %d documents, want %d: %q", len(gotDocs), len(want), got)
+	}
+	for i, w := range want {
+		assertJSONEqual(t, gotDocs[i], w)
+	}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	in := "{\"a\":1}\n{\"b\":2}\n"
+
+	var gronned bytes.Buffer
+	if err := Encode(strings.NewReader(in), &gronned, &Options{Monochrome: true, Stream: true}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := Decode(strings.NewReader(gronned.String()), &out, &Options{Monochrome: true, Stream: true}); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+
+	assertJSONDocsEqual(t, out.String(), []string{`{"a":1}`, `{"b":2}`})
+}
+
+// TestArrayRoundTripWithoutStream guards against a regression where a
+// plain top-level array - which also gron's to json[N] paths, just
+// like a stream does - was mistaken for streamed output and split
+// back into separate documents unless --stream was actually requested
+func TestArrayRoundTripWithoutStream(t *testing.T) {
+	in := `[1,2,3]`
+
+	var gronned bytes.Buffer
+	if err := Encode(strings.NewReader(in), &gronned, &Options{Monochrome: true}); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := Decode(strings.NewReader(gronned.String()), &out, &Options{Monochrome: true}); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+
+	assertJSONEqual(t, out.String(), in)
+}
@@ -0,0 +1,85 @@
+package gron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// statementFromString parses a single line of gron-style assignment
+// output (e.g. `json.foo[0] = "bar"`) back into a statement
+func statementFromString(s string) statement {
+	parts := strings.SplitN(s, " = ", 2)
+	if len(parts) != 2 {
+		return statement{}
+	}
+
+	path := parsePath(parts[0])
+	value := strings.TrimSpace(parts[1])
+
+	return statement{
+		path:  path,
+		value: value,
+		typ:   typOfValue(value),
+	}
+}
+
+// parsePath turns "json.foo["bar baz"][0]" into a slice of tokens
+func parsePath(s string) []token {
+	var path []token
+	i := 0
+	for i < len(s) {
+		switch {
+		case s[i] == '.':
+			i++
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				return path
+			}
+			inner := s[i+1 : i+end]
+			i += end + 1
+			if len(inner) >= 2 && inner[0] == '"' {
+				var unquoted string
+				fmt.Sscanf(inner, "%q", &unquoted)
+				path = append(path, token{text: unquoted, typ: typQuotedKey})
+			} else {
+				path = append(path, token{text: inner, typ: typIndex})
+			}
+		default:
+			end := i
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			path = append(path, token{text: s[i:end], typ: typBare})
+			i = end
+		}
+	}
+	return path
+}
+
+func typOfValue(v string) valueTyp {
+	switch {
+	case v == "null":
+		return typNull
+	case v == "true" || v == "false":
+		return typBool
+	case v == "{}":
+		return typEmptyObject
+	case v == "[]":
+		return typEmptyArray
+	case len(v) > 0 && v[0] == '"':
+		return typString
+	case isDatetimeLiteral(v):
+		return typDatetime
+	default:
+		return typNumber
+	}
+}
+
+// isDatetimeLiteral reports whether v is a bare RFC 3339 datetime,
+// gron's textual representation of a typDatetime statement
+func isDatetimeLiteral(v string) bool {
+	_, err := time.Parse(time.RFC3339Nano, v)
+	return err == nil
+}
@@ -0,0 +1,27 @@
+package gron
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestUngronPreservesLargeIntegers guards against a regression where
+// statement.interfaceValue unmarshaled a number into interface{},
+// forcing it through float64 and corrupting integers wider than 2^53
+// on plain ungron, even though the forward (gron) direction already
+// preserved them via json.Decoder.UseNumber
+func TestUngronPreservesLargeIntegers(t *testing.T) {
+	in := "json.id = 9007199254740993\njson.big = 12345678901234567890\n"
+
+	var out bytes.Buffer
+	if err := Decode(strings.NewReader(in), &out, &Options{Monochrome: true}); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+
+	for _, want := range []string{"9007199254740993", "12345678901234567890"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
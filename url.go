@@ -0,0 +1,10 @@
+package main
+
+import "regexp"
+
+var urlRegex = regexp.MustCompile(`^https?://`)
+
+// validURL returns true if s looks like an HTTP(S) URL
+func validURL(s string) bool {
+	return urlRegex.MatchString(s)
+}